@@ -0,0 +1,27 @@
+// Command lmb is the lmb CLI entrypoint.
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprintln(os.Stderr, "usage: lmb <command> [arguments]")
+		os.Exit(1)
+	}
+
+	var err error
+	switch os.Args[1] {
+	case "migrate":
+		err = runMigrate(os.Args[2:])
+	default:
+		fmt.Fprintf(os.Stderr, "lmb: unknown command %q\n", os.Args[1])
+		os.Exit(1)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "lmb:", err)
+		os.Exit(1)
+	}
+}
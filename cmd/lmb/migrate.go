@@ -0,0 +1,125 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log/slog"
+	"strconv"
+
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/henry40408/lmb/internal/store"
+)
+
+// runMigrate implements the `lmb migrate` command group (status, up,
+// down, force), each operating against the SQLite store at --dsn.
+func runMigrate(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: lmb migrate <status|up|down|force> [arguments]")
+	}
+	sub, rest := args[0], args[1:]
+
+	switch sub {
+	case "status":
+		return runMigrateStatus(rest)
+	case "up":
+		return runMigrateUp(rest)
+	case "down":
+		return runMigrateDown(rest)
+	case "force":
+		return runMigrateForce(rest)
+	default:
+		return fmt.Errorf("unknown migrate subcommand %q", sub)
+	}
+}
+
+func runMigrateStatus(args []string) error {
+	fs := flag.NewFlagSet("migrate status", flag.ExitOnError)
+	dsn := fs.String("dsn", "lmb.db", "SQLite DSN to inspect")
+	dryRun := fs.Bool("dry-run", false, "list pending migrations from the embedded source without executing them")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if *dryRun {
+		pending, err := store.PendingMigrations(*dsn)
+		if err != nil {
+			return err
+		}
+		if len(pending) == 0 {
+			fmt.Println("up to date, no pending migrations")
+			return nil
+		}
+		fmt.Println("pending migrations:")
+		for _, v := range pending {
+			fmt.Printf("  %d\n", v)
+		}
+		return nil
+	}
+
+	mg, err := store.NewMigrator(*dsn, slog.Default())
+	if err != nil {
+		return err
+	}
+	defer mg.Close()
+
+	version, dirty, err := mg.Version()
+	if err != nil && err != migrate.ErrNilVersion {
+		return err
+	}
+	fmt.Printf("version: %d (dirty: %t)\n", version, dirty)
+	return nil
+}
+
+func runMigrateUp(args []string) error {
+	fs := flag.NewFlagSet("migrate up", flag.ExitOnError)
+	dsn := fs.String("dsn", "lmb.db", "SQLite DSN to migrate")
+	n := fs.Int("n", 0, "number of migrations to apply (0 = all pending)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	mg, err := store.NewMigrator(*dsn, slog.Default())
+	if err != nil {
+		return err
+	}
+	defer mg.Close()
+	return mg.Up(*n)
+}
+
+func runMigrateDown(args []string) error {
+	fs := flag.NewFlagSet("migrate down", flag.ExitOnError)
+	dsn := fs.String("dsn", "lmb.db", "SQLite DSN to migrate")
+	n := fs.Int("n", 0, "number of migrations to roll back (0 = all)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	mg, err := store.NewMigrator(*dsn, slog.Default())
+	if err != nil {
+		return err
+	}
+	defer mg.Close()
+	return mg.Down(*n)
+}
+
+func runMigrateForce(args []string) error {
+	fs := flag.NewFlagSet("migrate force", flag.ExitOnError)
+	dsn := fs.String("dsn", "lmb.db", "SQLite DSN to migrate")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: lmb migrate force <version> [--dsn=...]")
+	}
+	version, err := strconv.Atoi(fs.Arg(0))
+	if err != nil {
+		return fmt.Errorf("invalid version %q: %w", fs.Arg(0), err)
+	}
+
+	mg, err := store.NewMigrator(*dsn, slog.Default())
+	if err != nil {
+		return err
+	}
+	defer mg.Close()
+	return mg.Force(version)
+}
@@ -0,0 +1,83 @@
+package lua
+
+import lua "github.com/yuin/gopher-lua"
+
+// goToLua converts a value returned by store.Store (see codec.go's
+// encodeValue/decodeValue for the supported set) into its Lua
+// equivalent.
+func goToLua(L *lua.LState, value interface{}) lua.LValue {
+	switch v := value.(type) {
+	case nil:
+		return lua.LNil
+	case bool:
+		return lua.LBool(v)
+	case int64:
+		return lua.LNumber(v)
+	case float64:
+		return lua.LNumber(v)
+	case string:
+		return lua.LString(v)
+	case []byte:
+		return lua.LString(v)
+	case map[string]interface{}:
+		t := L.NewTable()
+		for k, vv := range v {
+			L.SetField(t, k, goToLua(L, vv))
+		}
+		return t
+	case []interface{}:
+		t := L.NewTable()
+		for i, vv := range v {
+			L.RawSetInt(t, i+1, goToLua(L, vv))
+		}
+		return t
+	default:
+		return lua.LNil
+	}
+}
+
+// luaToGo converts a Lua value back into one of the types store.Store's
+// codec layer knows how to persist.
+func luaToGo(value lua.LValue) interface{} {
+	switch v := value.(type) {
+	case *lua.LNilType:
+		return nil
+	case lua.LBool:
+		return bool(v)
+	case lua.LNumber:
+		return float64(v)
+	case lua.LString:
+		return string(v)
+	case *lua.LTable:
+		return luaTableToGo(v)
+	default:
+		return nil
+	}
+}
+
+// luaTableToGo converts t to a []interface{} if it looks like a
+// sequential array (1..n with no holes), otherwise to a
+// map[string]interface{}.
+func luaTableToGo(t *lua.LTable) interface{} {
+	n := t.Len()
+	if n > 0 {
+		arr := make([]interface{}, 0, n)
+		for i := 1; i <= n; i++ {
+			val := t.RawGetInt(i)
+			if val == lua.LNil {
+				arr = nil
+				break
+			}
+			arr = append(arr, luaToGo(val))
+		}
+		if arr != nil {
+			return arr
+		}
+	}
+
+	m := map[string]interface{}{}
+	t.ForEach(func(k, val lua.LValue) {
+		m[k.String()] = luaToGo(val)
+	})
+	return m
+}
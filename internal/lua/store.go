@@ -0,0 +1,85 @@
+// Package lua exposes lmb's internal/store.Store to Lua scripts.
+package lua
+
+import (
+	"github.com/henry40408/lmb/internal/store"
+	lua "github.com/yuin/gopher-lua"
+)
+
+const storeTypeName = "lmb.store"
+
+// RegisterStore exposes s to L as the global "store" userdata, with
+// get/put/update methods mirroring store.Store.
+func RegisterStore(L *lua.LState, s store.Store) {
+	mt := L.NewTypeMetatable(storeTypeName)
+	L.SetField(mt, "__index", L.SetFuncs(L.NewTable(), storeMethods))
+
+	ud := L.NewUserData()
+	ud.Value = s
+	ud.Metatable = mt
+	L.SetGlobal("store", ud)
+}
+
+var storeMethods = map[string]lua.LGFunction{
+	"get":    storeGet,
+	"put":    storePut,
+	"update": storeUpdate,
+}
+
+func checkStore(L *lua.LState) store.Store {
+	ud := L.CheckUserData(1)
+	s, ok := ud.Value.(store.Store)
+	if !ok {
+		L.ArgError(1, "store expected")
+		return nil
+	}
+	return s
+}
+
+func storeGet(L *lua.LState) int {
+	s := checkStore(L)
+	name := L.CheckString(2)
+	value, err := s.Get(name)
+	if err != nil {
+		L.RaiseError("store:get(%q): %v", name, err)
+		return 0
+	}
+	L.Push(goToLua(L, value))
+	return 1
+}
+
+func storePut(L *lua.LState) int {
+	s := checkStore(L)
+	name := L.CheckString(2)
+	value := L.CheckAny(3)
+	if err := s.Put(name, luaToGo(value)); err != nil {
+		L.RaiseError("store:put(%q): %v", name, err)
+	}
+	return 0
+}
+
+// storeUpdate implements store:update("key", function(v) ... end): fn is
+// called with the current value (nil if absent) and must return the new
+// value, all inside store.Store.Update's atomic read-modify-write.
+func storeUpdate(L *lua.LState) int {
+	s := checkStore(L)
+	name := L.CheckString(2)
+	fn := L.CheckFunction(3)
+
+	updated, err := s.Update(name, func(old interface{}) (interface{}, error) {
+		L.Push(fn)
+		L.Push(goToLua(L, old))
+		if err := L.PCall(1, 1, nil); err != nil {
+			return nil, err
+		}
+		ret := L.Get(-1)
+		L.Pop(1)
+		return luaToGo(ret), nil
+	})
+	if err != nil {
+		L.RaiseError("store:update(%q): %v", name, err)
+		return 0
+	}
+	L.Push(goToLua(L, updated))
+	return 1
+}
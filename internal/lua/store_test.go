@@ -0,0 +1,97 @@
+package lua
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/henry40408/lmb/internal/store"
+	luaState "github.com/yuin/gopher-lua"
+)
+
+func newTestStore(t *testing.T) store.Store {
+	t.Helper()
+	s, err := store.NewSQLiteStore(":memory:")
+	if err != nil {
+		t.Fatalf("NewSQLiteStore: %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+func TestStoreUpdateFromLua(t *testing.T) {
+	s := newTestStore(t)
+
+	L := luaState.NewState()
+	defer L.Close()
+	RegisterStore(L, s)
+
+	script := `
+		return store:update("counter", function(v)
+			if v == nil then
+				return 1
+			end
+			return v + 1
+		end)
+	`
+	if err := L.DoString(script); err != nil {
+		t.Fatalf("DoString: %v", err)
+	}
+	ret := L.Get(-1)
+	L.Pop(1)
+	if got, want := ret.String(), "1"; got != want {
+		t.Fatalf("first update: got %v, want %v", got, want)
+	}
+
+	if err := L.DoString(script); err != nil {
+		t.Fatalf("DoString: %v", err)
+	}
+	ret = L.Get(-1)
+	L.Pop(1)
+	if got, want := ret.String(), "2"; got != want {
+		t.Fatalf("second update: got %v, want %v", got, want)
+	}
+
+	got, err := s.Get("counter")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if got != int64(2) && got != float64(2) {
+		t.Fatalf("expected stored counter to be 2, got %v (%T)", got, got)
+	}
+}
+
+func TestStoreUpdateFromLuaIsAtomicUnderConcurrency(t *testing.T) {
+	s := newTestStore(t)
+	if err := s.Put("counter", int64(0)); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+
+	const n = 20
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			L := luaState.NewState()
+			defer L.Close()
+			RegisterStore(L, s)
+			err := L.DoString(`
+				store:update("counter", function(v)
+					return v + 1
+				end)
+			`)
+			if err != nil {
+				t.Errorf("DoString: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	got, err := s.Get("counter")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if got != int64(n) && got != float64(n) {
+		t.Fatalf("expected counter == %d after %d concurrent increments, got %v", n, n, got)
+	}
+}
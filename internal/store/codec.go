@@ -0,0 +1,104 @@
+package store
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+)
+
+// Type hints written to the store's type_hint column. Each identifies the
+// codec used to encode the payload, so Get can dispatch back to the exact
+// Go type Put was given and non-Go tooling can read the column directly.
+// They're namespaced (rather than plain "bool", "string", ...) so they
+// never collide with the reflect.Type names legacy gob rows were tagged
+// with; migrateLegacyGobRows uses that to tell old rows from new ones.
+const (
+	codecNil     = "codec:nil"
+	codecBool    = "codec:bool"
+	codecInt64   = "codec:int64"
+	codecFloat64 = "codec:float64"
+	codecString  = "codec:string"
+	codecBytes   = "codec:bytes"
+	codecTable   = "codec:table"
+	codecArray   = "codec:array"
+)
+
+// isCodecHint reports whether hint was written by encodeValue, as opposed
+// to a legacy reflect.Type name left over from the gob era.
+func isCodecHint(hint string) bool {
+	switch hint {
+	case codecNil, codecBool, codecInt64, codecFloat64, codecString, codecBytes, codecTable, codecArray:
+		return true
+	default:
+		return false
+	}
+}
+
+// encodeValue picks a codec for value and returns its payload and type
+// hint. Numbers from Lua arrive as float64 (Go callers may also pass
+// int64 or int), and tables as map[string]interface{} or
+// []interface{}; anything else is rejected
+// rather than silently gob-encoded.
+func encodeValue(value interface{}) (payload []byte, typeHint string, err error) {
+	switch v := value.(type) {
+	case nil:
+		return nil, codecNil, nil
+	case bool:
+		if v {
+			return []byte{1}, codecBool, nil
+		}
+		return []byte{0}, codecBool, nil
+	case int64:
+		buf := make([]byte, 8)
+		binary.BigEndian.PutUint64(buf, uint64(v))
+		return buf, codecInt64, nil
+	case int:
+		return encodeValue(int64(v))
+	case float64:
+		buf := make([]byte, 8)
+		binary.BigEndian.PutUint64(buf, math.Float64bits(v))
+		return buf, codecFloat64, nil
+	case string:
+		return []byte(v), codecString, nil
+	case []byte:
+		return v, codecBytes, nil
+	case map[string]interface{}:
+		b, err := json.Marshal(v)
+		return b, codecTable, err
+	case []interface{}:
+		b, err := json.Marshal(v)
+		return b, codecArray, err
+	default:
+		return nil, "", fmt.Errorf("store: unsupported value type %T", value)
+	}
+}
+
+// decodeValue reverses encodeValue given the payload and type hint stored
+// alongside it.
+func decodeValue(payload []byte, typeHint string) (interface{}, error) {
+	switch typeHint {
+	case codecNil:
+		return nil, nil
+	case codecBool:
+		return len(payload) > 0 && payload[0] != 0, nil
+	case codecInt64:
+		return int64(binary.BigEndian.Uint64(payload)), nil
+	case codecFloat64:
+		return math.Float64frombits(binary.BigEndian.Uint64(payload)), nil
+	case codecString:
+		return string(payload), nil
+	case codecBytes:
+		return payload, nil
+	case codecTable:
+		var m map[string]interface{}
+		err := json.Unmarshal(payload, &m)
+		return m, err
+	case codecArray:
+		var a []interface{}
+		err := json.Unmarshal(payload, &a)
+		return a, err
+	default:
+		return nil, fmt.Errorf("store: unknown type hint %q", typeHint)
+	}
+}
@@ -0,0 +1,133 @@
+package store
+
+import (
+	"bytes"
+	"encoding/gob"
+	"reflect"
+	"testing"
+)
+
+func TestEncodeDecodeValueRoundtrip(t *testing.T) {
+	cases := []struct {
+		name string
+		in   interface{}
+	}{
+		{"nil", nil},
+		{"bool true", true},
+		{"bool false", false},
+		{"int64", int64(42)},
+		{"int", int(42)},
+		{"float64", float64(3.5)},
+		{"string", "hello"},
+		{"bytes", []byte("hello")},
+		{"table", map[string]interface{}{"a": "b"}},
+		{"array", []interface{}{"a", "b", "c"}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			payload, typeHint, err := encodeValue(c.in)
+			if err != nil {
+				t.Fatalf("encodeValue(%v): %v", c.in, err)
+			}
+			if !isCodecHint(typeHint) {
+				t.Fatalf("encodeValue(%v) produced non-codec type_hint %q", c.in, typeHint)
+			}
+			got, err := decodeValue(payload, typeHint)
+			if err != nil {
+				t.Fatalf("decodeValue: %v", err)
+			}
+			want := c.in
+			if want == nil {
+				if got != nil {
+					t.Fatalf("got %v, want nil", got)
+				}
+				return
+			}
+			if i, ok := want.(int); ok {
+				want = int64(i)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Fatalf("roundtrip mismatch: got %#v, want %#v", got, want)
+			}
+		})
+	}
+}
+
+func TestEncodeValueRejectsUnsupportedType(t *testing.T) {
+	type unsupported struct{ X int }
+	if _, _, err := encodeValue(unsupported{X: 1}); err == nil {
+		t.Fatal("expected an error for an unsupported type, got nil")
+	}
+}
+
+func TestIsCodecHintRejectsLegacyReflectNames(t *testing.T) {
+	for _, legacy := range []string{"string", "bool", "int", "float64", "MyStruct"} {
+		if isCodecHint(legacy) {
+			t.Fatalf("isCodecHint(%q) = true, want false (legacy gob type_hint must never collide)", legacy)
+		}
+	}
+}
+
+func TestSQLiteStorePutGetRoundtrip(t *testing.T) {
+	s := newTestSQLiteStore(t)
+
+	if err := s.Put("k", "hello"); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	got, err := s.Get("k")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if got != "hello" {
+		t.Fatalf("got %v, want %q", got, "hello")
+	}
+
+	var size int64
+	if err := s.db.QueryRow(`SELECT size FROM store WHERE name = ?`, "k").Scan(&size); err != nil {
+		t.Fatalf("scan size: %v", err)
+	}
+	if size != int64(len("hello")) {
+		t.Fatalf("size = %d, want real payload length %d", size, len("hello"))
+	}
+}
+
+func TestMigrateLegacyGobRows(t *testing.T) {
+	s := newTestSQLiteStore(t)
+
+	legacyPayload := serializeGobForTest(t, "legacy-value")
+	if _, err := s.db.Exec(SQL_UPSERT, "legacy", legacyPayload, "string", int64(len(legacyPayload))); err != nil {
+		t.Fatalf("seed legacy row: %v", err)
+	}
+
+	if err := migrateLegacyGobRows(s.db); err != nil {
+		t.Fatalf("migrateLegacyGobRows: %v", err)
+	}
+
+	got, err := s.Get("legacy")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if got != "legacy-value" {
+		t.Fatalf("got %v, want %q", got, "legacy-value")
+	}
+
+	var typeHint string
+	if err := s.db.QueryRow(`SELECT type_hint FROM store WHERE name = ?`, "legacy").Scan(&typeHint); err != nil {
+		t.Fatalf("scan type_hint: %v", err)
+	}
+	if !isCodecHint(typeHint) {
+		t.Fatalf("expected legacy row to be rewritten with a codec type_hint, got %q", typeHint)
+	}
+}
+
+// serializeGobForTest mirrors the pre-codec encoder this package used to
+// call on Put, so migrateLegacyGobRows has a real legacy row to rewrite.
+func serializeGobForTest(t *testing.T, value interface{}) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	var asInterface interface{} = value
+	if err := gob.NewEncoder(&buf).Encode(&asInterface); err != nil {
+		t.Fatalf("gob encode: %v", err)
+	}
+	return buf.Bytes()
+}
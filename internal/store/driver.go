@@ -0,0 +1,17 @@
+package store
+
+import (
+	"database/sql"
+
+	"github.com/golang-migrate/migrate/v4/database"
+)
+
+// driver abstracts the differences between the SQLite driver
+// implementations lmb can be built against: the database/sql driver name
+// to pass to sql.Open, and how to obtain a golang-migrate database.Driver
+// for it. Exactly one of driver_cgo.go / driver_modernc.go is compiled in,
+// selected by the "sqlite_cgo" build tag.
+type driver struct {
+	name             string
+	newMigrateDriver func(db *sql.DB) (database.Driver, error)
+}
@@ -0,0 +1,26 @@
+//go:build sqlite_cgo
+
+package store
+
+import (
+	"database/sql"
+
+	"github.com/golang-migrate/migrate/v4/database"
+	"github.com/golang-migrate/migrate/v4/database/sqlite3"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// sqliteDriver selects mattn/go-sqlite3 when lmb is built with the
+// sqlite_cgo tag (-tags sqlite_cgo), which also requires CGO_ENABLED=1
+// and a C toolchain. "cgo" itself is one of Go's implicit build tags
+// (satisfied by an ordinary CGO_ENABLED=1 build) so it can't be used to
+// opt in here: that would make the mattn/go-sqlite3 driver the default
+// on any machine with a C toolchain, the opposite of what we want. Kept
+// around for deployments that already have a C toolchain and want the
+// most battle-tested driver.
+var sqliteDriver = driver{
+	name: "sqlite3",
+	newMigrateDriver: func(db *sql.DB) (database.Driver, error) {
+		return sqlite3.WithInstance(db, &sqlite3.Config{})
+	},
+}
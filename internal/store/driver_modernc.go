@@ -0,0 +1,23 @@
+//go:build !sqlite_cgo
+
+package store
+
+import (
+	"database/sql"
+
+	"github.com/golang-migrate/migrate/v4/database"
+	"github.com/golang-migrate/migrate/v4/database/sqlite"
+	_ "modernc.org/sqlite"
+)
+
+// sqliteDriver is the default build: modernc.org/sqlite, a pure-Go driver
+// that lets lmb cross-compile (static musl, Windows, wasm) without a C
+// toolchain. An ordinary "go build", even with CGO_ENABLED=1, picks this
+// file; building github.com/mattn/go-sqlite3 instead requires explicitly
+// opting in with -tags sqlite_cgo (see driver_cgo.go).
+var sqliteDriver = driver{
+	name: "sqlite",
+	newMigrateDriver: func(db *sql.DB) (database.Driver, error) {
+		return sqlite.WithInstance(db, &sqlite.Config{})
+	},
+}
@@ -0,0 +1,163 @@
+package store
+
+import (
+	"database/sql"
+	"io/fs"
+	"log/slog"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+	"github.com/henry40408/lmb/migrations"
+)
+
+// Migrator exposes the embedded schema migrations as a first-class
+// subsystem, independent of NewSQLiteStore's silent m.Up() at open time,
+// so the `lmb migrate` CLI command group can inspect version, force it,
+// or roll back.
+type Migrator struct {
+	m      *migrate.Migrate
+	logger *slog.Logger
+}
+
+// NewMigrator opens a Migrator against the SQLite database at dsn. It
+// does not run any migrations itself.
+func NewMigrator(dsn string, logger *slog.Logger) (*Migrator, error) {
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	db, err := sql.Open(sqliteDriver.name, dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	d, err := iofs.New(migrations.MigrationFiles, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	mdriver, err := sqliteDriver.newMigrateDriver(db)
+	if err != nil {
+		return nil, err
+	}
+
+	m, err := migrate.NewWithInstance("iofs", d, sqliteDriver.name, mdriver)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Migrator{m: m, logger: logger}, nil
+}
+
+// Close releases the underlying source and database handles.
+func (mg *Migrator) Close() error {
+	srcErr, dbErr := mg.m.Close()
+	if srcErr != nil {
+		return srcErr
+	}
+	return dbErr
+}
+
+// Version returns the currently applied migration version, and whether
+// the database was left in a dirty (partially-applied) state.
+func (mg *Migrator) Version() (version uint, dirty bool, err error) {
+	return mg.m.Version()
+}
+
+// Up applies the next n pending migrations, or all of them if n <= 0.
+func (mg *Migrator) Up(n int) error {
+	var err error
+	if n <= 0 {
+		err = mg.m.Up()
+	} else {
+		err = mg.m.Steps(n)
+	}
+	return mg.logResult("up", err)
+}
+
+// Down rolls back the last n applied migrations, or all of them if
+// n <= 0.
+func (mg *Migrator) Down(n int) error {
+	var err error
+	if n <= 0 {
+		err = mg.m.Down()
+	} else {
+		err = mg.m.Steps(-n)
+	}
+	return mg.logResult("down", err)
+}
+
+// Force sets the migration version without running any migration body,
+// clearing a dirty flag left by a failed migration.
+func (mg *Migrator) Force(v int) error {
+	return mg.logResult("force", mg.m.Force(v))
+}
+
+// Steps applies n migrations forward, or -n backward if n is negative.
+func (mg *Migrator) Steps(n int) error {
+	return mg.logResult("steps", mg.m.Steps(n))
+}
+
+func (mg *Migrator) logResult(op string, err error) error {
+	if err != nil && err != migrate.ErrNoChange {
+		return err
+	}
+	version, dirty, verr := mg.m.Version()
+	if verr == nil {
+		mg.logger.Info("migration applied", "op", op, "version", version, "dirty", dirty)
+	}
+	return nil
+}
+
+// PendingMigrations lists migration versions in the embedded source newer
+// than dsn's current version, without executing them. It backs
+// `lmb migrate status --dry-run`.
+func PendingMigrations(dsn string) ([]uint, error) {
+	mg, err := NewMigrator(dsn, slog.Default())
+	if err != nil {
+		return nil, err
+	}
+	defer mg.Close()
+
+	current, _, err := mg.Version()
+	if err != nil && err != migrate.ErrNilVersion {
+		return nil, err
+	}
+
+	entries, err := fs.ReadDir(migrations.MigrationFiles, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	seen := map[uint]bool{}
+	for _, e := range entries {
+		version, ok := leadingVersion(e.Name())
+		if ok && version > current {
+			seen[version] = true
+		}
+	}
+
+	pending := make([]uint, 0, len(seen))
+	for v := range seen {
+		pending = append(pending, v)
+	}
+	sort.Slice(pending, func(i, j int) bool { return pending[i] < pending[j] })
+	return pending, nil
+}
+
+// leadingVersion parses the numeric prefix off a migration file name,
+// e.g. "0002_add_expires_at.up.sql" -> 2.
+func leadingVersion(name string) (uint, bool) {
+	prefix, _, found := strings.Cut(name, "_")
+	if !found {
+		return 0, false
+	}
+	v, err := strconv.ParseUint(prefix, 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return uint(v), true
+}
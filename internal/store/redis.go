@@ -0,0 +1,175 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisStore is the shared, multi-node Store implementation backed by
+// Redis. Values are marshaled as JSON rather than gob so that non-Go
+// clients can read and write the same keys.
+type RedisStore struct {
+	client *redis.Client
+}
+
+// NewRedisStore opens a Store against the Redis instance described by
+// dsn, e.g. "redis://host:6379/0".
+func NewRedisStore(dsn string) (*RedisStore, error) {
+	opts, err := redis.ParseURL(dsn)
+	if err != nil {
+		return nil, err
+	}
+	return &RedisStore{client: redis.NewClient(opts)}, nil
+}
+
+func (s *RedisStore) Close() error {
+	return s.client.Close()
+}
+
+func (s *RedisStore) Get(name string) (interface{}, error) {
+	value, err := s.client.Get(context.Background(), name).Bytes()
+	if err != nil {
+		if err == redis.Nil {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var deserialized interface{}
+	if err := json.Unmarshal(value, &deserialized); err != nil {
+		return nil, err
+	}
+	return deserialized, nil
+}
+
+func (s *RedisStore) Put(name string, value interface{}) error {
+	serialized, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return s.client.Set(context.Background(), name, serialized, 0).Err()
+}
+
+// PutWithTTL implements Store.PutWithTTL as a native Redis SET ... EX.
+func (s *RedisStore) PutWithTTL(name string, value interface{}, ttl time.Duration) error {
+	serialized, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return s.client.Set(context.Background(), name, serialized, ttl).Err()
+}
+
+// Expire implements Store.Expire as a native Redis EXPIRE.
+func (s *RedisStore) Expire(name string, ttl time.Duration) error {
+	return s.client.Expire(context.Background(), name, ttl).Err()
+}
+
+// Update implements Store.Update using Redis WATCH for optimistic
+// concurrency: it watches name, re-reads it inside the watch, runs fn,
+// and writes back in a MULTI/EXEC pipeline. go-redis aborts with
+// redis.TxFailedErr if name changed between the watch and the exec, in
+// which case Update retries.
+func (s *RedisStore) Update(name string, fn func(old interface{}) (interface{}, error)) (interface{}, error) {
+	ctx := context.Background()
+	deadline := time.Now().Add(5 * time.Second)
+	backoff := 10 * time.Millisecond
+	for {
+		var result interface{}
+		err := s.client.Watch(ctx, func(tx *redis.Tx) error {
+			old, err := getTx(ctx, tx, name)
+			if err != nil {
+				return err
+			}
+			updated, err := fn(old)
+			if err != nil {
+				return err
+			}
+			payload, err := json.Marshal(updated)
+			if err != nil {
+				return err
+			}
+			_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+				pipe.Set(ctx, name, payload, 0)
+				return nil
+			})
+			result = updated
+			return err
+		}, name)
+		if err == nil {
+			return result, nil
+		}
+		if err != redis.TxFailedErr || time.Now().After(deadline) {
+			return nil, err
+		}
+		time.Sleep(backoff)
+		if backoff < time.Second {
+			backoff *= 2
+		}
+	}
+}
+
+func getTx(ctx context.Context, tx *redis.Tx, name string) (interface{}, error) {
+	value, err := tx.Get(ctx, name).Bytes()
+	if err != nil {
+		if err == redis.Nil {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var deserialized interface{}
+	if err := json.Unmarshal(value, &deserialized); err != nil {
+		return nil, err
+	}
+	return deserialized, nil
+}
+
+func (s *RedisStore) Begin() (StoreTx, error) {
+	return &RedisStoreTx{client: s.client, pipe: s.client.TxPipeline()}, nil
+}
+
+// RedisStoreTx is the Redis StoreTx implementation. Writes are queued on
+// a MULTI/EXEC pipeline and only take effect when Commit flushes it;
+// Rollback discards the pipeline so none of them do. Get reads directly
+// against the client rather than the pipeline: a read isn't a mutation,
+// so there's nothing for Rollback to undo, and queuing it would force an
+// early Exec to hand back its value, which would flush whatever writes
+// were already queued before Commit was ever called.
+type RedisStoreTx struct {
+	client *redis.Client
+	pipe   redis.Pipeliner
+}
+
+func (st *RedisStoreTx) Get(name string) (interface{}, error) {
+	value, err := st.client.Get(context.Background(), name).Bytes()
+	if err != nil {
+		if err == redis.Nil {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var deserialized interface{}
+	if err := json.Unmarshal(value, &deserialized); err != nil {
+		return nil, err
+	}
+	return deserialized, nil
+}
+
+func (st *RedisStoreTx) Put(name string, value interface{}) error {
+	serialized, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return st.pipe.Set(context.Background(), name, serialized, 0).Err()
+}
+
+func (st *RedisStoreTx) Commit() error {
+	_, err := st.pipe.Exec(context.Background())
+	return err
+}
+
+func (st *RedisStoreTx) Rollback() error {
+	st.pipe.Discard()
+	return nil
+}
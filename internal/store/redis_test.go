@@ -0,0 +1,155 @@
+package store
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+var (
+	_ Store = (*RedisStore)(nil)
+	_ Store = (*SQLiteStore)(nil)
+)
+
+func newTestRedisStore(t *testing.T) (*RedisStore, *miniredis.Miniredis) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	s, err := NewRedisStore("redis://" + mr.Addr() + "/0")
+	if err != nil {
+		t.Fatalf("NewRedisStore: %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s, mr
+}
+
+func TestRedisStoreTxRollbackDiscardsQueuedWrites(t *testing.T) {
+	s, _ := newTestRedisStore(t)
+
+	tx, err := s.Begin()
+	if err != nil {
+		t.Fatalf("Begin: %v", err)
+	}
+	if err := tx.Put("k", "uncommitted-write"); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	if _, err := tx.Get("other-key"); err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if err := tx.Rollback(); err != nil {
+		t.Fatalf("Rollback: %v", err)
+	}
+
+	got, err := s.Get("k")
+	if err != nil {
+		t.Fatalf("Get after rollback: %v", err)
+	}
+	if got != nil {
+		t.Fatalf("expected k to be unset after rollback, got %v", got)
+	}
+}
+
+func TestRedisStorePutWithTTLExpires(t *testing.T) {
+	s, mr := newTestRedisStore(t)
+
+	if err := s.PutWithTTL("session:1", "value", time.Second); err != nil {
+		t.Fatalf("PutWithTTL: %v", err)
+	}
+	mr.FastForward(2 * time.Second)
+
+	got, err := s.Get("session:1")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if got != nil {
+		t.Fatalf("expected session:1 to have expired, got %v", got)
+	}
+}
+
+func TestRedisStoreExpireOnExistingKey(t *testing.T) {
+	s, mr := newTestRedisStore(t)
+
+	if err := s.Put("session:1", "value"); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	if err := s.Expire("session:1", time.Second); err != nil {
+		t.Fatalf("Expire: %v", err)
+	}
+	mr.FastForward(2 * time.Second)
+
+	got, err := s.Get("session:1")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if got != nil {
+		t.Fatalf("expected session:1 to have expired, got %v", got)
+	}
+}
+
+func TestRedisStoreTxCommitAppliesQueuedWrites(t *testing.T) {
+	s, _ := newTestRedisStore(t)
+
+	tx, err := s.Begin()
+	if err != nil {
+		t.Fatalf("Begin: %v", err)
+	}
+	if err := tx.Put("k", "committed-write"); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("Commit: %v", err)
+	}
+
+	got, err := s.Get("k")
+	if err != nil {
+		t.Fatalf("Get after commit: %v", err)
+	}
+	if got != "committed-write" {
+		t.Fatalf("expected k = %q, got %v", "committed-write", got)
+	}
+}
+
+// TestRedisStoreUpdateRetriesOnWatchConflict drives a handful of
+// goroutines through Update against the same key and same RedisStore,
+// each holding the watch window open briefly so their WATCHes genuinely
+// collide: every transaction but the first racer to EXEC gets
+// redis.TxFailedErr, and Update must retry rather than lose an
+// increment.
+func TestRedisStoreUpdateRetriesOnWatchConflict(t *testing.T) {
+	s, _ := newTestRedisStore(t)
+
+	if err := s.Put("counter", float64(0)); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+
+	const n = 5
+	var wg sync.WaitGroup
+	errs := make(chan error, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := s.Update("counter", func(old interface{}) (interface{}, error) {
+				time.Sleep(5 * time.Millisecond)
+				return old.(float64) + 1, nil
+			})
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("Update: %v", err)
+		}
+	}
+
+	got, err := s.Get("counter")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if got != float64(n) {
+		t.Fatalf("expected counter == %d after %d concurrent Updates, got %v", n, n, got)
+	}
+}
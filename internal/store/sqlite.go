@@ -0,0 +1,297 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/gob"
+	"fmt"
+	"time"
+
+	"github.com/golang-migrate/migrate/v4"
+	_ "github.com/golang-migrate/migrate/v4/source/file"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+	"github.com/henry40408/lmb/migrations"
+)
+
+const (
+	// SQL_GET's second parameter is the caller's "now" (unix seconds): a
+	// row with a past expires_at is treated as if it didn't exist, see
+	// PutWithTTL.
+	SQL_GET       = `SELECT value, type_hint FROM store WHERE name = ? AND (expires_at IS NULL OR expires_at > ?)`
+	SQL_UPSERT    = `INSERT OR REPLACE INTO store (name, value, type_hint, size) VALUES (?, ?, ?, ?)`
+	SQL_ALL_NAMES = `SELECT name, value, type_hint FROM store`
+)
+
+// SQLiteStore is the embedded, single-process Store implementation backed
+// by a SQLite database file.
+type SQLiteStore struct {
+	db             *sql.DB
+	retryDeadline  time.Duration
+	cancelCompact  context.CancelFunc
+	compactionDone chan struct{}
+}
+
+// Options tunes the connection pool and PRAGMAs NewSQLiteStoreWithOptions
+// applies. The zero value is not valid; start from DefaultOptions.
+// The PRAGMAs are run once through db.Exec, so they reach only the pooled
+// connection that executes them; with MaxOpenConns > 1, connections the
+// pool opens later keep SQLite's defaults for busy_timeout, synchronous,
+// cache_size and mmap_size.
+type Options struct {
+	// MaxOpenConns is the cap passed to sql.DB.SetMaxOpenConns. With WAL
+	// enabled, readers can safely share a pool; set to 1 to keep the
+	// historical single-connection behavior.
+	MaxOpenConns int
+	// BusyTimeout is PRAGMA busy_timeout, in milliseconds.
+	BusyTimeout int
+	// CacheSize is PRAGMA cache_size (negative values are KiB, per SQLite).
+	CacheSize int
+	// MmapSize is PRAGMA mmap_size, in bytes.
+	MmapSize int64
+	// UpdateRetryDeadline bounds how long Update retries on SQLITE_BUSY
+	// before giving up and returning the error.
+	UpdateRetryDeadline time.Duration
+	// CompactionInterval is how often the background goroutine sweeps
+	// expired rows (see PutWithTTL). Zero disables the sweep entirely.
+	CompactionInterval time.Duration
+}
+
+// DefaultOptions preserves the historical single-connection tuning.
+func DefaultOptions() Options {
+	return Options{
+		MaxOpenConns:        1,
+		BusyTimeout:         5000,
+		CacheSize:           -2000,
+		MmapSize:            0,
+		UpdateRetryDeadline: 5 * time.Second,
+		CompactionInterval:  time.Minute,
+	}
+}
+
+func migrateDB(db *sql.DB) error {
+	d, err := iofs.New(migrations.MigrationFiles, ".")
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+
+	mdriver, err := sqliteDriver.newMigrateDriver(db)
+	if err != nil {
+		return err
+	}
+	// defer mdriver.Close() // database is closed
+
+	m, err := migrate.NewWithInstance("iofs", d, sqliteDriver.name, mdriver)
+	if err != nil {
+		return err
+	}
+	// defer m.Close() // database is closed
+
+	if err := m.Up(); err != nil && err != migrate.ErrNoChange {
+		return err
+	}
+	return nil
+}
+
+// migrateLegacyGobRows rewrites rows left over from before the typed
+// codec layer, whose value column holds a gob-encoded interface{} and
+// whose type_hint holds a bare reflect.Type name (e.g. "string") rather
+// than one of the "codec:" tags encodeValue writes. It's idempotent:
+// rows already in the new format are left untouched.
+func migrateLegacyGobRows(db *sql.DB) error {
+	rows, err := db.Query(SQL_ALL_NAMES)
+	if err != nil {
+		return err
+	}
+	type legacyRow struct {
+		name  string
+		value []byte
+	}
+	var legacy []legacyRow
+	for rows.Next() {
+		var name, typeHint string
+		var value []byte
+		if err := rows.Scan(&name, &value, &typeHint); err != nil {
+			rows.Close()
+			return err
+		}
+		if !isCodecHint(typeHint) {
+			legacy = append(legacy, legacyRow{name, value})
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	rows.Close()
+
+	for _, row := range legacy {
+		var decoded interface{}
+		if err := deserializeData(row.value, &decoded); err != nil {
+			return err
+		}
+		payload, typeHint, err := encodeValue(decoded)
+		if err != nil {
+			return err
+		}
+		if _, err := db.Exec(SQL_UPSERT, row.name, payload, typeHint, int64(len(payload))); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// NewSQLiteStore opens (and migrates) a SQLite-backed Store at dsn using
+// DefaultOptions.
+func NewSQLiteStore(dsn string) (*SQLiteStore, error) {
+	return NewSQLiteStoreWithOptions(dsn, DefaultOptions())
+}
+
+// NewSQLiteStoreWithOptions opens (and migrates) a SQLite-backed Store at
+// dsn, applying opts' connection pool size and PRAGMA tuning.
+func NewSQLiteStoreWithOptions(dsn string, opts Options) (*SQLiteStore, error) {
+	db, err := sql.Open(sqliteDriver.name, dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	// https://github.com/mattn/go-sqlite3/issues/274#issuecomment-191597862
+	db.SetMaxOpenConns(opts.MaxOpenConns)
+
+	// https://github.com/dani-garcia/vaultwarden/blob/3dbfc484a54c41d1759646444b439da06445060b/src/db/mod.rs#L234
+	// https://github.com/dani-garcia/vaultwarden/blob/3dbfc484a54c41d1759646444b439da06445060b/src/db/mod.rs#L447
+	_, err = db.Exec(fmt.Sprintf(`
+    PRAGMA busy_timeout = %d;
+    PRAGMA foreign_keys = OFF;
+    PRAGMA journal_mode = wal;
+    PRAGMA synchronous = NORMAL;
+    PRAGMA cache_size = %d;
+    PRAGMA mmap_size = %d;
+  `, opts.BusyTimeout, opts.CacheSize, opts.MmapSize))
+	if err != nil {
+		return nil, err
+	}
+
+	err = migrateDB(db)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := migrateLegacyGobRows(db); err != nil {
+		return nil, err
+	}
+
+	s := &SQLiteStore{db: db, retryDeadline: opts.UpdateRetryDeadline}
+	if opts.CompactionInterval > 0 {
+		ctx, cancel := context.WithCancel(context.Background())
+		s.cancelCompact = cancel
+		s.compactionDone = make(chan struct{})
+		go s.runCompaction(ctx, opts.CompactionInterval)
+	}
+	return s, nil
+}
+
+func (s *SQLiteStore) Close() error {
+	if s.cancelCompact != nil {
+		s.cancelCompact()
+		<-s.compactionDone
+	}
+	return s.db.Close()
+}
+
+// deserializeData decodes a legacy gob-encoded value, kept around for
+// migrateLegacyGobRows.
+func deserializeData(value []byte, target interface{}) error {
+	decoder := gob.NewDecoder(bytes.NewBuffer(value))
+	return decoder.Decode(target)
+}
+
+func (s *SQLiteStore) Get(name string) (interface{}, error) {
+	stmt, err := s.db.Prepare(SQL_GET)
+	if err != nil {
+		return nil, err
+	}
+	var value []byte
+	var typeHint string
+	err = stmt.QueryRow(&name, time.Now().Unix()).Scan(&value, &typeHint)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		} else {
+			return nil, err
+		}
+	}
+	return decodeValue(value, typeHint)
+}
+
+func (s *SQLiteStore) Put(name string, value interface{}) error {
+	stmt, err := s.db.Prepare(SQL_UPSERT)
+	if err != nil {
+		return err
+	}
+	payload, typeHint, err := encodeValue(value)
+	if err != nil {
+		return err
+	}
+	_, err = stmt.Exec(&name, payload, typeHint, int64(len(payload)))
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (s *SQLiteStore) Begin() (StoreTx, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	return &SQLiteStoreTx{tx}, nil
+}
+
+// SQLiteStoreTx is the SQLite StoreTx implementation, backed by a
+// *sql.Tx.
+type SQLiteStoreTx struct {
+	tx *sql.Tx
+}
+
+func (st *SQLiteStoreTx) Rollback() error {
+	return st.tx.Rollback()
+}
+
+func (st *SQLiteStoreTx) Commit() error {
+	return st.tx.Commit()
+}
+
+func (st *SQLiteStoreTx) Get(name string) (interface{}, error) {
+	stmt, err := st.tx.Prepare(SQL_GET)
+	if err != nil {
+		return nil, err
+	}
+	var value []byte
+	var typeHint string
+	err = stmt.QueryRow(&name, time.Now().Unix()).Scan(&value, &typeHint)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		} else {
+			return nil, err
+		}
+	}
+	return decodeValue(value, typeHint)
+}
+
+func (st *SQLiteStoreTx) Put(name string, value interface{}) error {
+	stmt, err := st.tx.Prepare(SQL_UPSERT)
+	if err != nil {
+		return err
+	}
+	payload, typeHint, err := encodeValue(value)
+	if err != nil {
+		return err
+	}
+	_, err = stmt.Exec(&name, payload, typeHint, int64(len(payload)))
+	if err != nil {
+		return err
+	}
+	return nil
+}
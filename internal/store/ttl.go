@@ -0,0 +1,53 @@
+package store
+
+import (
+	"context"
+	"time"
+)
+
+const (
+	SQL_UPSERT_TTL    = `INSERT OR REPLACE INTO store (name, value, type_hint, size, expires_at) VALUES (?, ?, ?, ?, ?)`
+	SQL_EXPIRE        = `UPDATE store SET expires_at = ? WHERE name = ?`
+	SQL_PURGE_EXPIRED = `DELETE FROM store WHERE expires_at IS NOT NULL AND expires_at <= ?`
+	// SQL_GET_WITH_EXPIRY is SQL_GET plus the row's raw expires_at, so
+	// callers that rewrite the row (Store.Update) can carry the existing
+	// TTL forward instead of clobbering it via SQL_UPSERT.
+	SQL_GET_WITH_EXPIRY = `SELECT value, type_hint, expires_at FROM store WHERE name = ? AND (expires_at IS NULL OR expires_at > ?)`
+)
+
+// PutWithTTL stores value under name like Put, but marks the row to
+// expire after ttl. Once expired, Get treats the row as absent; the
+// background goroutine started by NewSQLiteStoreWithOptions eventually
+// deletes it outright.
+func (s *SQLiteStore) PutWithTTL(name string, value interface{}, ttl time.Duration) error {
+	payload, typeHint, err := encodeValue(value)
+	if err != nil {
+		return err
+	}
+	expiresAt := time.Now().Add(ttl).Unix()
+	_, err = s.db.Exec(SQL_UPSERT_TTL, name, payload, typeHint, int64(len(payload)), expiresAt)
+	return err
+}
+
+// Expire sets or refreshes the TTL on an existing key. It has no effect
+// if name doesn't exist.
+func (s *SQLiteStore) Expire(name string, ttl time.Duration) error {
+	expiresAt := time.Now().Add(ttl).Unix()
+	_, err := s.db.Exec(SQL_EXPIRE, expiresAt, name)
+	return err
+}
+
+// runCompaction periodically deletes expired rows until ctx is canceled.
+func (s *SQLiteStore) runCompaction(ctx context.Context, interval time.Duration) {
+	defer close(s.compactionDone)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			_, _ = s.db.Exec(SQL_PURGE_EXPIRED, time.Now().Unix())
+		}
+	}
+}
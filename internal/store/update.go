@@ -0,0 +1,97 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+)
+
+// isBusyErr reports whether err looks like SQLITE_BUSY / "database is
+// locked" from whichever SQLite driver is compiled in (driver_cgo.go /
+// driver_modernc.go use different error types for the same condition, so
+// this matches on message rather than a driver-specific sentinel).
+func isBusyErr(err error) bool {
+	if err == nil {
+		return false
+	}
+	msg := strings.ToLower(err.Error())
+	return strings.Contains(msg, "locked") || strings.Contains(msg, "busy")
+}
+
+// Update implements Store.Update by running fn inside a BEGIN IMMEDIATE
+// transaction, retrying with exponential backoff while SQLite reports
+// SQLITE_BUSY, up to s.retryDeadline.
+func (s *SQLiteStore) Update(name string, fn func(old interface{}) (interface{}, error)) (interface{}, error) {
+	deadline := time.Now().Add(s.retryDeadline)
+	backoff := 10 * time.Millisecond
+	for {
+		result, err := s.tryUpdate(name, fn)
+		if err == nil {
+			return result, nil
+		}
+		if !isBusyErr(err) || time.Now().After(deadline) {
+			return nil, err
+		}
+		time.Sleep(backoff)
+		if backoff < time.Second {
+			backoff *= 2
+		}
+	}
+}
+
+func (s *SQLiteStore) tryUpdate(name string, fn func(old interface{}) (interface{}, error)) (interface{}, error) {
+	ctx := context.Background()
+	conn, err := s.db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, "BEGIN IMMEDIATE"); err != nil {
+		return nil, err
+	}
+
+	var value []byte
+	var typeHint string
+	var expiresAt sql.NullInt64
+	var old interface{}
+	err = conn.QueryRowContext(ctx, SQL_GET_WITH_EXPIRY, name, time.Now().Unix()).Scan(&value, &typeHint, &expiresAt)
+	switch err {
+	case nil:
+		old, err = decodeValue(value, typeHint)
+		if err != nil {
+			_, _ = conn.ExecContext(ctx, "ROLLBACK")
+			return nil, err
+		}
+	case sql.ErrNoRows:
+		old = nil
+	default:
+		_, _ = conn.ExecContext(ctx, "ROLLBACK")
+		return nil, err
+	}
+
+	updated, err := fn(old)
+	if err != nil {
+		_, _ = conn.ExecContext(ctx, "ROLLBACK")
+		return nil, err
+	}
+
+	payload, typeHint, err := encodeValue(updated)
+	if err != nil {
+		_, _ = conn.ExecContext(ctx, "ROLLBACK")
+		return nil, err
+	}
+	// Carry the row's existing expires_at forward: SQL_UPSERT alone would
+	// INSERT OR REPLACE every column, silently clearing any TTL set by
+	// PutWithTTL/Expire on every single Update call.
+	if _, err := conn.ExecContext(ctx, SQL_UPSERT_TTL, name, payload, typeHint, int64(len(payload)), expiresAt); err != nil {
+		_, _ = conn.ExecContext(ctx, "ROLLBACK")
+		return nil, err
+	}
+
+	if _, err := conn.ExecContext(ctx, "COMMIT"); err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
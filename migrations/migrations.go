@@ -0,0 +1,8 @@
+// Package migrations embeds the SQL migration files applied to the
+// SQLite store by store.Migrator.
+package migrations
+
+import "embed"
+
+//go:embed *.sql
+var MigrationFiles embed.FS